@@ -0,0 +1,100 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"io"
+	"sync"
+	"syscall"
+)
+
+// dirInfo caches directory-enumeration state across repeated
+// (*File).readdir calls, so that a directory opened once and read in
+// chunks (the common Readdir(n) pattern) only pays for one
+// readDirEntriesBatchHandle enumeration no matter how many chunks are
+// requested.
+type dirInfo struct {
+	path string
+
+	mu      sync.Mutex
+	order   []string
+	entries map[string]dirBatchEntry
+	loaded  bool
+	pos     int
+}
+
+// load enumerates dir's directory handle once, on the first call; later
+// calls reuse the cached result.
+func (d *dirInfo) load(h syscall.Handle) error {
+	if d.loaded {
+		return nil
+	}
+	order, entries, err := readDirEntriesBatchHandle(h, d.path)
+	if err != nil {
+		return err
+	}
+	d.order = order
+	d.entries = entries
+	d.loaded = true
+	return nil
+}
+
+// readdir reads the contents of the directory associated with file and
+// returns a slice of up to n FileInfo values, in directory order. If n <=
+// 0, readdir returns all remaining entries in a single slice. When n > 0
+// and no entries remain, it returns an empty slice and io.EOF, matching
+// (*File).Readdir's documented behavior.
+//
+// Entries are produced from the single bulk enumeration cached in
+// file.dirinfo, made by the same readDirEntriesBatchHandle that StatBatch
+// and LstatBatch use, except for reparse points, which are reopened
+// individually with Lstat, the same way LstatBatch falls back for them.
+func (file *File) readdir(n int) ([]FileInfo, error) {
+	if file.dirinfo == nil {
+		file.dirinfo = &dirInfo{path: file.name}
+	}
+	d := file.dirinfo
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.load(file.pfd.Sysfd); err != nil {
+		return nil, &PathError{Op: "readdir", Path: d.path, Err: err}
+	}
+
+	want := len(d.order) - d.pos
+	if n > 0 && n < want {
+		want = n
+	}
+	if want == 0 {
+		if n > 0 {
+			return []FileInfo{}, io.EOF
+		}
+		return []FileInfo{}, nil
+	}
+
+	infos := make([]FileInfo, 0, want)
+	for ; want > 0; want-- {
+		name := d.order[d.pos]
+		d.pos++
+		ent := d.entries[foldKey(name)]
+		path := joinDirAndName(d.path, name)
+
+		var (
+			fi  FileInfo
+			err error
+		)
+		if ent.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+			fi, err = lstatNolog(path)
+		} else {
+			fi, err = fileStatFromDirBatchEntry(path, name, ent)
+		}
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, fi)
+	}
+	return infos, nil
+}
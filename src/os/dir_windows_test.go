@@ -0,0 +1,65 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileReaddirUsesBatchEnumeration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skipf("could not create symlink, skipping: %v", err)
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	infosByName := make(map[string]os.FileInfo)
+	for {
+		infos, err := f.Readdir(1)
+		for _, fi := range infos {
+			infosByName[fi.Name()] = fi
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantSizes := map[string]int64{"a.txt": 1, "b.txt": 2}
+	for name, size := range wantSizes {
+		fi, ok := infosByName[name]
+		if !ok {
+			t.Errorf("Readdir: entry %q not found", name)
+			continue
+		}
+		if fi.Size() != size {
+			t.Errorf("Readdir: entry %q has size %d, want %d", name, fi.Size(), size)
+		}
+	}
+
+	// link.txt is a reparse point; Readdir, like Lstat, reports the link
+	// itself rather than following it to a.txt.
+	if fi, ok := infosByName["link.txt"]; !ok {
+		t.Error("Readdir: entry \"link.txt\" not found")
+	} else if fi.Mode()&os.ModeSymlink == 0 {
+		t.Error("Readdir: entry \"link.txt\" is not reported as a symlink")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatBatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	linkPath := filepath.Join(dir, "link.txt")
+	if err := os.Symlink("a.txt", linkPath); err != nil {
+		t.Skipf("could not create symlink, skipping: %v", err)
+	}
+
+	names := []string{"a.txt", "B.TXT", "link.txt", "missing.txt"}
+	infos, errs := os.StatBatch(dir, names)
+	if len(infos) != len(names) || len(errs) != len(names) {
+		t.Fatalf("got %d infos and %d errs, want %d", len(infos), len(errs), len(names))
+	}
+
+	// a.txt: plain file, served from the batched directory enumeration.
+	if errs[0] != nil {
+		t.Errorf("a.txt: unexpected error: %v", errs[0])
+	} else if infos[0].Size() != 1 {
+		t.Errorf("a.txt: Size() = %d, want 1", infos[0].Size())
+	}
+
+	// B.TXT: same on-disk entry as b.txt, looked up case-insensitively.
+	if errs[1] != nil {
+		t.Errorf("B.TXT: unexpected error: %v", errs[1])
+	} else if infos[1].Size() != 2 {
+		t.Errorf("B.TXT: Size() = %d, want 2", infos[1].Size())
+	}
+
+	// link.txt: a reparse point, resolved through the non-batched
+	// fallback path; StatBatch follows the link, as Stat does.
+	if errs[2] != nil {
+		t.Errorf("link.txt: unexpected error: %v", errs[2])
+	} else if infos[2].Size() != 1 {
+		t.Errorf("link.txt: Size() = %d, want 1 (target of a.txt)", infos[2].Size())
+	}
+
+	// missing.txt: not present in dir.
+	if errs[3] == nil {
+		t.Error("missing.txt: expected an error, got nil")
+	}
+}
+
+func TestLstatBatch(t *testing.T) {
+	dir := t.TempDir()
+	linkPath := filepath.Join(dir, "link.txt")
+	if err := os.Symlink("does-not-exist.txt", linkPath); err != nil {
+		t.Skipf("could not create symlink, skipping: %v", err)
+	}
+
+	infos, errs := os.LstatBatch(dir, []string{"link.txt"})
+	if errs[0] != nil {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+	if infos[0].Mode()&os.ModeSymlink == 0 {
+		t.Error("LstatBatch did not report link.txt as a symlink")
+	}
+}
+
+func TestStatBatchCaseInsensitive(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "MixedCase.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"MixedCase.txt", "mixedcase.txt", "MIXEDCASE.TXT"} {
+		infos, errs := os.StatBatch(dir, []string{name})
+		if errs[0] != nil {
+			t.Errorf("StatBatch(%q): unexpected error: %v", name, errs[0])
+			continue
+		}
+		if !strings.EqualFold(infos[0].Name(), "MixedCase.txt") {
+			t.Errorf("StatBatch(%q): Name() = %q", name, infos[0].Name())
+		}
+	}
+}
@@ -10,6 +10,46 @@ import (
 	"unsafe"
 )
 
+// A WindowsFileInfo describes extended, Windows-specific metadata about a
+// file that isn't exposed by the FileInfo interface itself. It is obtained
+// from a FileInfo value produced by Stat, Lstat, File.Stat, StatBatch, or
+// LstatBatch via WindowsFileInfoFrom.
+//
+// The ReparseTag, FileID, VolumeSerialNumber, and NumberOfLinks fields are
+// only populated when the stat was resolved through an open file handle:
+// File.Stat on a non-directory always is, while Stat and Lstat only are for
+// reparse points or when GetFileAttributesEx fails. When GetFileAttributesEx
+// or FindFirstFile alone answered the stat, those fields are left zero,
+// since obtaining them would require reopening the file. The one exception
+// is FileID, which StatBatch and LstatBatch also fill in for non-reparse
+// entries from their directory enumeration.
+type WindowsFileInfo struct {
+	syscall.Win32FileAttributeData
+
+	// ReparseTag is the reparse point tag identifying the reparse point
+	// subtype (for example, a symbolic link, mount point, or a
+	// provider-specific placeholder such as OneDrive's). It is zero if
+	// the file isn't a reparse point, or if the tag wasn't determined.
+	ReparseTag uint32
+
+	// FileID is the 128-bit file identifier from FILE_ID_INFO. It is
+	// stable across renames and is only guaranteed unique within a
+	// single volume: two files with the same FileID but different
+	// VolumeSerialNumber are not the same file. To detect hard-link
+	// equivalence, compare FileID together with VolumeSerialNumber, the
+	// same way SameFile compares a file index together with a volume
+	// serial number. It is the zero value if it wasn't determined.
+	FileID [16]byte
+
+	// VolumeSerialNumber is the serial number of the volume that
+	// contains the file. It is zero if it wasn't determined.
+	VolumeSerialNumber uint32
+
+	// NumberOfLinks is the number of hard links to the file. It is zero
+	// if it wasn't determined.
+	NumberOfLinks uint32
+}
+
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (file *File) Stat() (FileInfo, error) {
@@ -96,9 +136,32 @@ func statHandle(name string, h syscall.Handle) (FileInfo, error) {
 		return nil, err
 	}
 	fs.filetype = ft
+	fs.fillExtendedInfo(h)
 	return fs, err
 }
 
+// fillExtendedInfo populates the 128-bit file ID and reparse tag via
+// GetFileInformationByHandleEx, best-effort: a failure on either call (for
+// example on older Windows releases, or filesystems that don't support
+// FILE_ID_INFO) just leaves that call's WindowsFileInfo fields zero,
+// independently of whether the other call succeeded. The volume serial
+// number reported alongside the file ID is not used here, since
+// newFileStatFromGetFileInformationByHandle already obtained it (and
+// NumberOfLinks) from the handle's BY_HANDLE_FILE_INFORMATION.
+func (fs *fileStat) fillExtendedInfo(h syscall.Handle) {
+	var idInfo windows.FILE_ID_INFO
+	if err := windows.GetFileInformationByHandleEx(h, windows.FileIdInfo, (*byte)(unsafe.Pointer(&idInfo)), uint32(unsafe.Sizeof(idInfo))); err == nil {
+		fs.fileID = idInfo.FileId
+		fs.haveFileID = true
+	}
+
+	var tagInfo windows.FILE_ATTRIBUTE_TAG_INFO
+	if err := windows.GetFileInformationByHandleEx(h, windows.FileAttributeTagInfo, (*byte)(unsafe.Pointer(&tagInfo)), uint32(unsafe.Sizeof(tagInfo))); err == nil {
+		fs.reparseTag = tagInfo.ReparseTag
+		fs.haveReparseTag = true
+	}
+}
+
 // statNolog implements Stat for Windows.
 func statNolog(name string) (FileInfo, error) {
 	return stat("Stat", name, syscall.FILE_FLAG_BACKUP_SEMANTICS)
@@ -0,0 +1,61 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWindowsFileInfoFrom(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// File.Stat on a regular file resolves through the open handle, which
+	// is what populates NumberOfLinks and FileID; os.Stat of a plain file
+	// is answered by GetFileAttributesEx alone and leaves them zero.
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sys must keep returning its original, documented concrete type so
+	// that existing callers doing a type assertion against it don't
+	// silently stop working.
+	if _, ok := fi.Sys().(*syscall.Win32FileAttributeData); !ok {
+		t.Fatalf("fi.Sys() has type %T, want *syscall.Win32FileAttributeData", fi.Sys())
+	}
+
+	info, ok := os.WindowsFileInfoFrom(fi)
+	if !ok {
+		t.Fatal("WindowsFileInfoFrom reported ok = false for a FileInfo from File.Stat")
+	}
+	if info.NumberOfLinks == 0 {
+		t.Error("NumberOfLinks = 0, want at least 1 for a regular file resolved through a handle")
+	}
+	var zeroID [16]byte
+	if info.FileID == zeroID {
+		t.Error("FileID is zero, want it to be populated for a regular file resolved through a handle")
+	}
+	if info.ReparseTag != 0 {
+		t.Errorf("ReparseTag = %#x, want 0 for a non-reparse-point file", info.ReparseTag)
+	}
+
+	if _, ok := os.WindowsFileInfoFrom(fakeFileInfo{}); ok {
+		t.Error("WindowsFileInfoFrom reported ok = true for a non-os FileInfo implementation")
+	}
+}
+
+type fakeFileInfo struct{ os.FileInfo }
@@ -0,0 +1,185 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package os
+
+import (
+	"syscall"
+	"time"
+)
+
+// A fileStat is the implementation of FileInfo returned by Stat and Lstat.
+type fileStat struct {
+	name string
+
+	filetype uint32 // one of syscall.FILE_TYPE_*, set by statHandle from GetFileType
+
+	// from GetFileAttributesEx, FindFirstFile, or GetFileInformationByHandle
+	FileAttributes uint32
+	CreationTime   syscall.Filetime
+	LastAccessTime syscall.Filetime
+	LastWriteTime  syscall.Filetime
+	FileSizeHigh   uint32
+	FileSizeLow    uint32
+
+	// used to implement SameFile
+	path             string
+	vol              uint32
+	idxhi            uint32
+	idxlo            uint32
+	appendNameToPath bool
+
+	// extended metadata, populated on a best-effort basis when the stat
+	// was resolved through a file handle (fileID also by StatBatch and
+	// LstatBatch from their directory enumeration); see
+	// WindowsFileInfoFrom. vol,
+	// above, doubles as the volume serial number exposed there, so it
+	// isn't duplicated here. Each concern tracks its own availability,
+	// since the handle-based BY_HANDLE_FILE_INFORMATION call that
+	// supplies numberOfLinks and vol can succeed independently of the
+	// two GetFileInformationByHandleEx calls that supply fileID and
+	// reparseTag (for example, on filesystems without FILE_ID_INFO
+	// support).
+	numberOfLinks  uint32
+	haveLinkInfo   bool
+	fileID         [16]byte
+	haveFileID     bool
+	reparseTag     uint32
+	haveReparseTag bool
+}
+
+func newFileStatFromWin32finddata(d *syscall.Win32finddata) *fileStat {
+	return &fileStat{
+		FileAttributes: d.FileAttributes,
+		CreationTime:   d.CreationTime,
+		LastAccessTime: d.LastAccessTime,
+		LastWriteTime:  d.LastWriteTime,
+		FileSizeHigh:   d.FileSizeHigh,
+		FileSizeLow:    d.FileSizeLow,
+	}
+}
+
+func newFileStatFromGetFileInformationByHandle(path string, h syscall.Handle) (*fileStat, error) {
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return nil, &PathError{Op: "GetFileInformationByHandle", Path: path, Err: err}
+	}
+
+	return &fileStat{
+		FileAttributes: d.FileAttributes,
+		CreationTime:   d.CreationTime,
+		LastAccessTime: d.LastAccessTime,
+		LastWriteTime:  d.LastWriteTime,
+		FileSizeHigh:   d.FileSizeHigh,
+		FileSizeLow:    d.FileSizeLow,
+		vol:            d.VolumeSerialNumber,
+		idxhi:          d.FileIndexHigh,
+		idxlo:          d.FileIndexLow,
+		numberOfLinks:  d.NumberOfLinks,
+		haveLinkInfo:   true,
+	}, nil
+}
+
+// saveInfoFromPath saves the full path of the file, to be used by
+// os.SameFile later, and sets name from path.
+func (fs *fileStat) saveInfoFromPath(path string) error {
+	fs.path = path
+	if !isAbs(fs.path) {
+		var err error
+		fs.path, err = syscall.FullPath(fs.path)
+		if err != nil {
+			return &PathError{Op: "FullPath", Path: path, Err: err}
+		}
+	}
+	fs.name = basename(path)
+	fs.appendNameToPath = true
+	return nil
+}
+
+func (fs *fileStat) Name() string { return fs.name }
+
+func (fs *fileStat) Size() int64 {
+	return int64(fs.FileSizeHigh)<<32 + int64(fs.FileSizeLow)
+}
+
+func (fs *fileStat) Mode() (m FileMode) {
+	if fs == nil {
+		return 0
+	}
+	if fs.FileAttributes&syscall.FILE_ATTRIBUTE_READONLY != 0 {
+		m |= 0444
+	} else {
+		m |= 0666
+	}
+	if fs.FileAttributes&syscall.FILE_ATTRIBUTE_DIRECTORY != 0 {
+		m |= ModeDir | 0111
+	}
+	switch fs.filetype {
+	case syscall.FILE_TYPE_PIPE:
+		m |= ModeNamedPipe
+	case syscall.FILE_TYPE_CHAR:
+		m |= ModeDevice | ModeCharDevice
+	}
+	if fs.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		m |= ModeSymlink
+	}
+	return m
+}
+
+func (fs *fileStat) ModTime() time.Time {
+	return time.Unix(0, fs.LastWriteTime.Nanoseconds())
+}
+
+func (fs *fileStat) IsDir() bool {
+	return fs.Mode().IsDir()
+}
+
+func (fs *fileStat) Sys() any {
+	return &syscall.Win32FileAttributeData{
+		FileAttributes: fs.FileAttributes,
+		CreationTime:   fs.CreationTime,
+		LastAccessTime: fs.LastAccessTime,
+		LastWriteTime:  fs.LastWriteTime,
+		FileSizeHigh:   fs.FileSizeHigh,
+		FileSizeLow:    fs.FileSizeLow,
+	}
+}
+
+// WindowsFileInfoFrom returns the extended, Windows-specific metadata for
+// fi, and reports whether fi carries it. fi must have been obtained from
+// Stat, Lstat, File.Stat, StatBatch, or LstatBatch; ok is false for any
+// other FileInfo implementation.
+//
+// This is a separate accessor, rather than a change to what fi.Sys()
+// returns, because fi.Sys() already has an established concrete type on
+// Windows (*syscall.Win32FileAttributeData) that existing callers type-assert
+// against; changing it out from under them would break that pattern
+// silently.
+func WindowsFileInfoFrom(fi FileInfo) (info WindowsFileInfo, ok bool) {
+	fs, ok := fi.(*fileStat)
+	if !ok {
+		return WindowsFileInfo{}, false
+	}
+	info = WindowsFileInfo{
+		Win32FileAttributeData: syscall.Win32FileAttributeData{
+			FileAttributes: fs.FileAttributes,
+			CreationTime:   fs.CreationTime,
+			LastAccessTime: fs.LastAccessTime,
+			LastWriteTime:  fs.LastWriteTime,
+			FileSizeHigh:   fs.FileSizeHigh,
+			FileSizeLow:    fs.FileSizeLow,
+		},
+	}
+	if fs.haveLinkInfo {
+		info.VolumeSerialNumber = fs.vol
+		info.NumberOfLinks = fs.numberOfLinks
+	}
+	if fs.haveFileID {
+		info.FileID = fs.fileID
+	}
+	if fs.haveReparseTag {
+		info.ReparseTag = fs.reparseTag
+	}
+	return info, true
+}